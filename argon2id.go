@@ -1,9 +1,12 @@
 package argon2id
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/base64"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"runtime"
@@ -17,7 +20,7 @@ func init() {
 }
 
 const (
-	template        = `$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s`
+	template        = `$%s$v=%d$m=%d,t=%d,p=%d$%s$%s`
 	versionTemplate = `v=%d`
 	paramsTemplate  = `m=%d,t=%d,p=%d`
 	splitChar       = "$"
@@ -34,40 +37,96 @@ const (
 )
 
 const (
-	algorithm                = `argon2id`
+	algorithm        = `argon2id`
+	argon2iAlgorithm = `argon2i`
+	argon2dAlgorithm = `argon2d`
+
 	defaultTimeCost   uint32 = 2     // 2 iterations
 	defaultMemoryCost uint32 = 65536 // 64MB memory cost
 	defaultSaltLength uint32 = 16    // bytes
 	defaultKeyLength  uint32 = 32    // bytes
 )
 
+const defaultVariant = Argon2id
+
 var defaultParallelismCost uint8
 
 var (
 	ErrMalformedHash       = errors.New("malformed hash")
 	ErrBytesNotRead        = errors.New("bytes not read")
 	ErrPasswordsDoNotMatch = errors.New("passwords do not match")
+	ErrIncompatibleVersion = errors.New("incompatible argon2 version")
+	ErrUnsupportedVariant  = errors.New("unsupported argon2 variant")
 )
 
+// Variant selects which member of the Argon2 family (RFC 9106) an
+// Argon2Id instance hashes and compares with.
+type Variant int
+
+const (
+	// Argon2id is hybrid and the recommended default: resistant to both
+	// side-channel and GPU cracking attacks.
+	Argon2id Variant = iota
+	// Argon2i is optimized for resistance to side-channel attacks.
+	Argon2i
+	// Argon2d is optimized for resistance to GPU cracking attacks, but
+	// golang.org/x/crypto/argon2 doesn't implement it; using it returns
+	// ErrUnsupportedVariant.
+	Argon2d
+)
+
+func (v Variant) String() string {
+	switch v {
+	case Argon2id:
+		return algorithm
+	case Argon2i:
+		return argon2iAlgorithm
+	case Argon2d:
+		return argon2dAlgorithm
+	default:
+		return "unknown"
+	}
+}
+
+func parseVariant(s string) (Variant, error) {
+	switch s {
+	case algorithm:
+		return Argon2id, nil
+	case argon2iAlgorithm:
+		return Argon2i, nil
+	case argon2dAlgorithm:
+		return Argon2d, nil
+	default:
+		return 0, fmt.Errorf("argon2id: algorithm: %w", ErrMalformedHash)
+	}
+}
+
 type Argon2Id struct {
+	variant         Variant
 	timeCost        uint32
 	memoryCost      uint32
 	parallelismCost uint8
 	saltLength      uint32
 	keyLength       uint32
+	secret          []byte
+	associatedData  []byte
 }
 
 // Creates a new Argon2Id instance.
 //
 // Available options:
 //
+//	WithVariant(Variant) -- Argon2id, Argon2i or Argon2d. Default Argon2id.
 //	WithTimeCost(uint32) -- 2 or greater is recommended. Default 2.
 //	WithMemoryCost(uint32) -- 65536 (64MB) or greater is recommended. Default 65536.
 //	WithParallelismCost(uint8) -- 1 or greater is recommended. Default runtime.NumCPU().
 //	WithSaltLength(uint32) -- 16 or greater is recommended. Default 16.
 //	WithKeyLength(uint32) -- 32 or greater is recommended. Default 32.
+//	WithSecret([]byte) -- a server-side pepper mixed into the KDF. Default nil (off).
+//	WithAssociatedData([]byte) -- domain-separation data mixed into the KDF. Default nil (off).
 func New(options ...Argon2IdOption) *Argon2Id {
 	a := &Argon2Id{
+		variant:         defaultVariant,
 		timeCost:        defaultTimeCost,
 		memoryCost:      defaultMemoryCost,
 		parallelismCost: defaultParallelismCost,
@@ -84,7 +143,61 @@ func New(options ...Argon2IdOption) *Argon2Id {
 	return a
 }
 
-// Generate an argon2id encoded hash from the source bytes.
+// deriveKey runs the KDF for variant, returning ErrUnsupportedVariant for
+// variants golang.org/x/crypto/argon2 doesn't implement (currently
+// Argon2d).
+func deriveKey(variant Variant, password, salt []byte, timeCost, memoryCost uint32, parallelismCost uint8, keyLength uint32) ([]byte, error) {
+	switch variant {
+	case Argon2id:
+		return argon2.IDKey(password, salt, timeCost, memoryCost, parallelismCost, keyLength), nil
+	case Argon2i:
+		return argon2.Key(password, salt, timeCost, memoryCost, parallelismCost, keyLength), nil
+	default:
+		return nil, fmt.Errorf("argon2id: %w", ErrUnsupportedVariant)
+	}
+}
+
+// preprocessPassword folds a's secret (pepper) and associated data into
+// password via HMAC-SHA256 before it reaches the argon2 KDF.
+//
+// golang.org/x/crypto/argon2 doesn't expose RFC 9106's secret/AAD
+// parameters, so this is a pragmatic stand-in: the secret keys an HMAC
+// over a length-prefixed password followed by associatedData, which
+// still gets the essential properties (a derived hash that's useless
+// without the secret; a derived hash that changes with the associated
+// data) without forking the argon2 implementation. The length prefix on
+// password is load-bearing: without it, password="foo"/associatedData="bar"
+// and password="foob"/associatedData="ar" would hash identically, since
+// HMAC sees the same byte stream either way. Secret and associated data
+// are never encoded into the hash string -- they're server-side inputs
+// supplied by the receiver's configuration on every call, including
+// Compare. If you rotate the secret, keep the old Argon2Id instance (or
+// an Argon2IdOption slice) around and retry Compare with it before
+// treating a login as a mismatch.
+func (a *Argon2Id) preprocessPassword(password []byte) []byte {
+	if len(a.secret) == 0 && len(a.associatedData) == 0 {
+		return password
+	}
+
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write(binary.BigEndian.AppendUint64(nil, uint64(len(password))))
+	mac.Write(password)
+	mac.Write(a.associatedData)
+	return mac.Sum(nil)
+}
+
+// Generate an encoded hash from the source bytes, using a's configured
+// Variant.
+//
+// Generate allocates memoryCost's full working buffer on every call, via
+// argon2.IDKey/argon2.Key. Pooling that buffer across calls -- the
+// dominant GC-pressure source for login-heavy services -- was attempted
+// and reverted: golang.org/x/crypto/argon2 exposes no hook to hand it a
+// caller-owned scratch slice, so the only buffer a sync.Pool could reuse
+// here was the 16-byte-by-default salt, which doesn't move the needle.
+// Doing this for real needs a vendored, modified copy of the argon2 core
+// that accepts a scratch buffer, the way minio's pkg/argon2 fork does;
+// that's a bigger undertaking than this package carries today.
 func (a *Argon2Id) Generate(password []byte) (string, error) {
 	salt := make([]byte, a.saltLength, a.saltLength)
 	n, err := rand.Read(salt)
@@ -95,57 +208,131 @@ func (a *Argon2Id) Generate(password []byte) (string, error) {
 		return "", fmt.Errorf("argon2id: generate salt: %w", ErrBytesNotRead)
 	}
 
-	key := argon2.IDKey(password, salt, a.timeCost, a.memoryCost, a.parallelismCost, a.keyLength)
+	key, err := deriveKey(a.variant, a.preprocessPassword(password), salt, a.timeCost, a.memoryCost, a.parallelismCost, a.keyLength)
+	if err != nil {
+		return "", fmt.Errorf("argon2id: generate: %w", err)
+	}
 
 	b64Salt := base64.RawStdEncoding.EncodeToString(salt)
 	b64Key := base64.RawStdEncoding.EncodeToString(key)
 
-	return fmt.Sprintf(template, argon2.Version, a.memoryCost, a.timeCost, a.parallelismCost, b64Salt, b64Key), nil
+	return fmt.Sprintf(template, a.variant, argon2.Version, a.memoryCost, a.timeCost, a.parallelismCost, b64Salt, b64Key), nil
 }
 
-// Compare raw password bytes with an argon2id encoded hash.
-func (a *Argon2Id) Compare(password []byte, hash string) error {
+// decodedHash holds the parameters and raw bytes parsed out of an
+// encoded argon2 hash string.
+type decodedHash struct {
+	variant         Variant
+	version         int
+	timeCost        uint32
+	memoryCost      uint32
+	parallelismCost uint8
+	salt            []byte
+	key             []byte
+}
+
+// decode parses a modular-crypt argon2 hash string into its variant,
+// parameters and raw salt/key bytes. It's shared by Compare and
+// NeedsRehash so the two never drift out of sync on what counts as a
+// well-formed hash.
+func decode(hash string) (*decodedHash, error) {
 	parts := strings.Split(hash, splitChar)
 	if len(parts) != numParts {
-		return fmt.Errorf("argon2id: parts: %w", ErrMalformedHash)
+		return nil, fmt.Errorf("argon2id: parts: %w", ErrMalformedHash)
 	}
 
-	if parts[algorithmIdx] != algorithm {
-		return fmt.Errorf("argon2id: algorithm: %w", ErrMalformedHash)
+	variant, err := parseVariant(parts[algorithmIdx])
+	if err != nil {
+		return nil, err
 	}
 
 	var version int
 	if _, err := fmt.Sscanf(parts[versionIdx], versionTemplate, &version); err != nil {
-		return fmt.Errorf("argon2id: scan version: %w", err)
+		return nil, fmt.Errorf("argon2id: scan version: %w", err)
 	}
 
 	var timeCost, memoryCost uint32
 	var parallelismCost uint8
 	if _, err := fmt.Sscanf(parts[paramsIdx], paramsTemplate, &memoryCost, &timeCost, &parallelismCost); err != nil {
-		return fmt.Errorf("argon2id: scan params: %w", err)
+		return nil, fmt.Errorf("argon2id: scan params: %w", err)
 	}
 
 	salt, err := base64.RawStdEncoding.Strict().DecodeString(parts[saltIdx])
 	if err != nil {
-		return fmt.Errorf("argon2id: decode salt: %w", err)
+		return nil, fmt.Errorf("argon2id: decode salt: %w", err)
 	}
 
 	key, err := base64.RawStdEncoding.Strict().DecodeString(parts[keyIdx])
 	if err != nil {
-		return fmt.Errorf("argon2id: decode key: %w", err)
+		return nil, fmt.Errorf("argon2id: decode key: %w", err)
 	}
 
-	comparisonKey := argon2.IDKey(password, salt, timeCost, memoryCost, parallelismCost, uint32(len(key)))
+	return &decodedHash{
+		variant:         variant,
+		version:         version,
+		timeCost:        timeCost,
+		memoryCost:      memoryCost,
+		parallelismCost: parallelismCost,
+		salt:            salt,
+		key:             key,
+	}, nil
+}
 
-	if subtle.ConstantTimeCompare(key, comparisonKey) == matching {
+// Compare raw password bytes with an encoded argon2 hash. The hash's own
+// prefix selects the variant used to recompute the key -- a's Variant
+// only governs Generate -- while secret and associated data always come
+// from a's configuration.
+func (a *Argon2Id) Compare(password []byte, hash string) error {
+	d, err := decode(hash)
+	if err != nil {
+		return err
+	}
+
+	comparisonKey, err := deriveKey(d.variant, a.preprocessPassword(password), d.salt, d.timeCost, d.memoryCost, d.parallelismCost, uint32(len(d.key)))
+	if err != nil {
+		return fmt.Errorf("argon2id: compare: %w", err)
+	}
+
+	if subtle.ConstantTimeCompare(d.key, comparisonKey) == matching {
 		return nil
 	}
 
 	return fmt.Errorf("argon2id: %w", ErrPasswordsDoNotMatch)
 }
 
+// NeedsRehash reports whether hash was produced with a weaker variant or
+// parameters (or an older argon2 version) than a's current
+// configuration, so callers can transparently upgrade a user's hash on
+// successful login. It returns ErrIncompatibleVersion, wrapped, if hash
+// was produced by a different argon2 version than the one this package
+// links against.
+func (a *Argon2Id) NeedsRehash(hash string) (bool, error) {
+	d, err := decode(hash)
+	if err != nil {
+		return false, err
+	}
+
+	if d.version != argon2.Version {
+		return false, fmt.Errorf("argon2id: %w", ErrIncompatibleVersion)
+	}
+
+	return d.variant != a.variant ||
+		d.memoryCost < a.memoryCost ||
+		d.timeCost < a.timeCost ||
+		d.parallelismCost < a.parallelismCost ||
+		uint32(len(d.salt)) < a.saltLength ||
+		uint32(len(d.key)) < a.keyLength, nil
+}
+
 type Argon2IdOption func(*Argon2Id)
 
+// WithVariant selects which Argon2 variant Generate produces. Argon2d is
+// accepted here but Generate/Compare will return ErrUnsupportedVariant
+// for it, since golang.org/x/crypto/argon2 doesn't implement it.
+func WithVariant(variant Variant) Argon2IdOption {
+	return func(a *Argon2Id) { a.variant = variant }
+}
+
 // A value of 2 or greater is recommended.
 func WithTimeCost(timeCost uint32) Argon2IdOption {
 	return func(a *Argon2Id) { a.timeCost = timeCost }
@@ -170,3 +357,19 @@ func WithSaltLength(saltLength uint32) Argon2IdOption {
 func WithKeyLength(keyLength uint32) Argon2IdOption {
 	return func(a *Argon2Id) { a.keyLength = keyLength }
 }
+
+// WithSecret sets a server-side pepper that's mixed into the KDF via
+// HMAC (see preprocessPassword) but, unlike the salt, is never encoded
+// into the hash string. To rotate a secret, keep an Argon2Id configured
+// with the previous one around and retry Compare with it before
+// rejecting a login.
+func WithSecret(secret []byte) Argon2IdOption {
+	return func(a *Argon2Id) { a.secret = secret }
+}
+
+// WithAssociatedData sets domain-separation data (e.g. a tenant or
+// purpose identifier) that's mixed into the KDF alongside the secret.
+// Like the secret, it's never encoded into the hash string.
+func WithAssociatedData(associatedData []byte) Argon2IdOption {
+	return func(a *Argon2Id) { a.associatedData = associatedData }
+}
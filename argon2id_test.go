@@ -52,6 +52,25 @@ func assertCompare(t *testing.T, wantErr error, gotErr error) {
 	}
 }
 
+func TestNeedsRehash(t *testing.T) {
+	weak := New(WithTimeCost(1), WithMemoryCost(1024), WithParallelismCost(1), WithSaltLength(8), WithKeyLength(16))
+	strong := New(WithTimeCost(4), WithMemoryCost(64*1024), WithParallelismCost(2), WithSaltLength(16), WithKeyLength(32))
+
+	hash, err := weak.Generate([]byte("password"))
+	assertGenerate(t, hash, nil, err)
+
+	needsRehash, err := strong.NeedsRehash(hash)
+	assert(t, err == nil, "needs rehash: unexpected err: %v", err)
+	assert(t, needsRehash, "needs rehash: want true, got false")
+
+	needsRehash, err = weak.NeedsRehash(hash)
+	assert(t, err == nil, "needs rehash: unexpected err: %v", err)
+	assert(t, !needsRehash, "needs rehash: want false, got true")
+
+	_, err = strong.NeedsRehash("not a hash")
+	assertCompare(t, ErrMalformedHash, err)
+}
+
 func TestArgon2Id(t *testing.T) {
 	iters := 25
 	if count := os.Getenv("TEST_ITERS"); count != "" {
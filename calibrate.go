@@ -0,0 +1,137 @@
+package argon2id
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// ErrCalibrationCeilingTooLow is returned by CalibrateMemory when
+// maxMemory is too small to even run a probe.
+var ErrCalibrationCeilingTooLow = errors.New("calibration memory ceiling too low")
+
+const (
+	// calibrationKeyLength and calibrationSaltLength don't need to match
+	// defaultKeyLength/defaultSaltLength -- they only need to be
+	// representative of real usage, since timing is dominated by
+	// memoryCost and timeCost, not key/salt size.
+	calibrationKeyLength  uint32 = defaultKeyLength
+	calibrationSaltLength uint32 = defaultSaltLength
+
+	// maxCalibrationTimeCost bounds the doubling search in
+	// calibrateTimeCost so a target the hardware can't realistically hit
+	// fails fast instead of looping forever.
+	maxCalibrationTimeCost uint32 = 1 << 20
+
+	// minCalibrationMemoryCost is the floor CalibrateMemory's search
+	// starts from: argon2 itself requires memoryCost >= 8*parallelism, so
+	// anything smaller isn't worth probing.
+	minCalibrationMemoryCost uint32 = 8
+)
+
+var (
+	calibrationPassword = []byte("argon2id-calibration-probe")
+	calibrationSalt     = make([]byte, calibrationSaltLength)
+)
+
+// probe times a single argon2.IDKey call at the given parameters using
+// fixed dummy inputs. Its result is never a real hash; it only exists to
+// measure wall-clock cost.
+func probe(timeCost, memoryCost uint32, parallelismCost uint8) time.Duration {
+	start := time.Now()
+	_ = argon2.IDKey(calibrationPassword, calibrationSalt, timeCost, memoryCost, parallelismCost, calibrationKeyLength)
+	return time.Since(start)
+}
+
+// Calibrate empirically tunes timeCost so that a single Generate from
+// the returned Argon2Id takes approximately target, holding memoryCost
+// at min(defaultMemoryCost, maxMemory) and parallelism at
+// runtime.NumCPU(). It probes argon2.IDKey directly with dummy inputs: a
+// few doubling probes to bracket target, then a binary search within
+// that bracket, for O(log timeCost) total probes.
+//
+// Calibrate is a deployment-time tool, not something to call per
+// request -- a single probe costs as much as a real Generate call.
+func Calibrate(target time.Duration, maxMemory uint32) (*Argon2Id, error) {
+	memoryCost := defaultMemoryCost
+	if maxMemory > 0 && maxMemory < memoryCost {
+		memoryCost = maxMemory
+	}
+
+	timeCost, err := calibrateTimeCost(memoryCost, defaultParallelismCost, target)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(
+		WithTimeCost(timeCost),
+		WithMemoryCost(memoryCost),
+		WithParallelismCost(defaultParallelismCost),
+	), nil
+}
+
+// CalibrateMemory is Calibrate's counterpart: it holds timeCost fixed at
+// defaultTimeCost and instead searches memoryCost (up to maxMemory) for
+// the value that makes a single Generate take approximately target.
+func CalibrateMemory(target time.Duration, maxMemory uint32) (*Argon2Id, error) {
+	memoryCost, err := calibrateMemoryCost(defaultTimeCost, defaultParallelismCost, target, maxMemory)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(
+		WithTimeCost(defaultTimeCost),
+		WithMemoryCost(memoryCost),
+		WithParallelismCost(defaultParallelismCost),
+	), nil
+}
+
+func calibrateTimeCost(memoryCost uint32, parallelismCost uint8, target time.Duration) (uint32, error) {
+	low, high := uint32(1), uint32(1)
+
+	for probe(high, memoryCost, parallelismCost) < target {
+		if high >= maxCalibrationTimeCost {
+			return 0, fmt.Errorf("argon2id: calibrate: time cost exceeds %d probing for %s", maxCalibrationTimeCost, target)
+		}
+		low, high = high, high*2
+	}
+
+	for high-low > 1 {
+		mid := low + (high-low)/2
+		if probe(mid, memoryCost, parallelismCost) < target {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+
+	return high, nil
+}
+
+func calibrateMemoryCost(timeCost uint32, parallelismCost uint8, target time.Duration, maxMemory uint32) (uint32, error) {
+	if maxMemory < minCalibrationMemoryCost {
+		return 0, fmt.Errorf("argon2id: calibrate memory: %w", ErrCalibrationCeilingTooLow)
+	}
+
+	low, high := minCalibrationMemoryCost, minCalibrationMemoryCost
+
+	for probe(timeCost, high, parallelismCost) < target {
+		if high >= maxMemory {
+			return maxMemory, nil
+		}
+		low, high = high, min(high*2, maxMemory)
+	}
+
+	for high-low > minCalibrationMemoryCost {
+		mid := low + (high-low)/2
+		if probe(timeCost, mid, parallelismCost) < target {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+
+	return high, nil
+}
@@ -0,0 +1,31 @@
+package argon2id
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalibrate(t *testing.T) {
+	a, err := Calibrate(5*time.Millisecond, 8*1024)
+	assert(t, err == nil, "calibrate: unexpected err: %v", err)
+	assert(t, a.memoryCost <= 8*1024, "calibrate: memoryCost %d exceeds maxMemory", a.memoryCost)
+	assert(t, a.timeCost >= 1, "calibrate: timeCost must be at least 1, got %d", a.timeCost)
+
+	hash, err := a.Generate([]byte("password"))
+	assertGenerate(t, hash, nil, err)
+	assertCompare(t, nil, a.Compare([]byte("password"), hash))
+}
+
+func TestCalibrateMemory(t *testing.T) {
+	a, err := CalibrateMemory(5*time.Millisecond, 8*1024)
+	assert(t, err == nil, "calibrate memory: unexpected err: %v", err)
+	assert(t, a.memoryCost <= 8*1024, "calibrate memory: memoryCost %d exceeds maxMemory", a.memoryCost)
+	assert(t, a.timeCost == defaultTimeCost, "calibrate memory: want timeCost %d, got %d", defaultTimeCost, a.timeCost)
+
+	hash, err := a.Generate([]byte("password"))
+	assertGenerate(t, hash, nil, err)
+	assertCompare(t, nil, a.Compare([]byte("password"), hash))
+
+	_, err = CalibrateMemory(5*time.Millisecond, 1)
+	assertCompare(t, ErrCalibrationCeilingTooLow, err)
+}
@@ -0,0 +1,119 @@
+package argon2id
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrUnknownScheme = errors.New("unknown scheme")
+	ErrNoSchemes     = errors.New("no schemes registered")
+)
+
+// Scheme is a pluggable password hashing algorithm identified by the
+// modular-crypt prefix it claims (e.g. "$argon2id$", "$2b$"). A Hasher
+// dispatches to the Scheme that Supports a given encoded hash, so that a
+// single Hasher can verify hashes produced by several algorithms while
+// only ever generating new ones with its primary scheme.
+type Scheme interface {
+	// Prefix returns the modular-crypt prefix this scheme claims, e.g.
+	// "$argon2id$" or "$2b$".
+	Prefix() string
+	// Supports reports whether hash was produced by this scheme.
+	Supports(hash string) bool
+	// Hash produces a new encoded hash for password using this scheme's
+	// current configuration.
+	Hash(password []byte) (string, error)
+	// Verify compares password against hash. hash must belong to this
+	// scheme; callers should route through a Hasher rather than call this
+	// directly unless they already know the scheme.
+	Verify(password []byte, hash string) error
+	// NeedsUpdate reports whether hash was produced with weaker parameters
+	// than this scheme's current configuration.
+	NeedsUpdate(hash string) bool
+}
+
+// Hasher manages a prioritized list of Schemes identified by their
+// modular-crypt prefix. The first registered scheme is the primary: it's
+// the only one Hash uses, and Verify upgrades any hash produced by a
+// different (or weaker) scheme to a fresh hash from the primary. This is
+// the pattern hlandau/passlib calls a CryptContext, and it lets a service
+// migrate password schemes over time without a flag day.
+type Hasher struct {
+	schemes []Scheme
+}
+
+// NewHasher creates a Hasher with the given schemes registered in
+// priority order; the first is the primary.
+func NewHasher(schemes ...Scheme) *Hasher {
+	h := &Hasher{}
+	for _, scheme := range schemes {
+		h.Register(scheme)
+	}
+	return h
+}
+
+// Register adds scheme to h. The first scheme ever registered is the
+// primary used by Hash and as the upgrade target for Verify.
+func (h *Hasher) Register(scheme Scheme) {
+	h.schemes = append(h.schemes, scheme)
+}
+
+// Hash produces a new encoded hash for password using the primary scheme.
+func (h *Hasher) Hash(password []byte) (string, error) {
+	if len(h.schemes) == 0 {
+		return "", fmt.Errorf("argon2id: hash: %w", ErrNoSchemes)
+	}
+	return h.schemes[0].Hash(password)
+}
+
+// Verify compares password against hash, dispatching to whichever
+// registered Scheme claims hash's prefix. If hash was produced by a
+// non-primary scheme, or by the primary scheme with parameters weaker
+// than its current configuration, Verify also returns a freshly computed
+// hash from the primary scheme for the caller to persist. upgradedHash is
+// empty when no upgrade is needed.
+//
+// password is only ever correct or incorrect based on scheme.Verify: a
+// failure to compute the opportunistic upgrade hash (e.g. a transient
+// rand.Read error) doesn't change that, so it's reported by leaving
+// upgradedHash empty rather than by failing the call -- a caller must
+// never treat a non-nil err here as "wrong password".
+func (h *Hasher) Verify(password []byte, hash string) (upgradedHash string, err error) {
+	scheme, err := h.lookup(hash)
+	if err != nil {
+		return "", err
+	}
+
+	if err := scheme.Verify(password, hash); err != nil {
+		return "", err
+	}
+
+	if scheme.Prefix() != h.schemes[0].Prefix() || scheme.NeedsUpdate(hash) {
+		if upgraded, err := h.schemes[0].Hash(password); err == nil {
+			upgradedHash = upgraded
+		}
+	}
+
+	return upgradedHash, nil
+}
+
+// NeedsUpdate reports whether hash should be replaced: either it wasn't
+// produced by the primary scheme, or it was but with parameters weaker
+// than the primary's current configuration.
+func (h *Hasher) NeedsUpdate(hash string) bool {
+	scheme, err := h.lookup(hash)
+	if err != nil {
+		return false
+	}
+	return scheme.Prefix() != h.schemes[0].Prefix() || scheme.NeedsUpdate(hash)
+}
+
+func (h *Hasher) lookup(hash string) (Scheme, error) {
+	for _, scheme := range h.schemes {
+		if scheme.Supports(hash) {
+			return scheme, nil
+		}
+	}
+	return nil, fmt.Errorf("argon2id: %w", ErrUnknownScheme)
+}
@@ -0,0 +1,47 @@
+package argon2id
+
+import (
+	"errors"
+	"strings"
+)
+
+// argon2iScheme adapts an *Argon2Id configured with WithVariant(Argon2i)
+// to the Scheme interface, so argon2i hashes can be verified (and
+// upgraded) by a Hasher whose primary scheme is argon2id.
+type argon2iScheme struct {
+	a *Argon2Id
+}
+
+// NewArgon2IScheme wraps a as a Scheme for use with a Hasher. a should be
+// configured with WithVariant(Argon2i).
+func NewArgon2IScheme(a *Argon2Id) Scheme {
+	return &argon2iScheme{a: a}
+}
+
+func (s *argon2iScheme) Prefix() string { return "$" + argon2iAlgorithm + "$" }
+
+func (s *argon2iScheme) Supports(hash string) bool {
+	return strings.HasPrefix(hash, s.Prefix())
+}
+
+func (s *argon2iScheme) Hash(password []byte) (string, error) {
+	return s.a.Generate(password)
+}
+
+func (s *argon2iScheme) Verify(password []byte, hash string) error {
+	return s.a.Compare(password, hash)
+}
+
+// NeedsUpdate treats ErrIncompatibleVersion as needing an update: a hash
+// produced by a stale argon2 version is exactly the drift NeedsRehash
+// exists to flag, and Hasher.Verify only offers its opportunistic
+// upgrade when NeedsUpdate reports true. Any other decode error (e.g.
+// ErrMalformedHash) means hash wasn't well-formed, so Verify will
+// already have failed before NeedsUpdate is ever consulted.
+func (s *argon2iScheme) NeedsUpdate(hash string) bool {
+	needsRehash, err := s.a.NeedsRehash(hash)
+	if err != nil {
+		return errors.Is(err, ErrIncompatibleVersion)
+	}
+	return needsRehash
+}
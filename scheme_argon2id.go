@@ -0,0 +1,45 @@
+package argon2id
+
+import (
+	"errors"
+	"strings"
+)
+
+// argon2idScheme adapts *Argon2Id to the Scheme interface so it can be
+// registered with a Hasher.
+type argon2idScheme struct {
+	a *Argon2Id
+}
+
+// NewArgon2IdScheme wraps a as a Scheme for use with a Hasher.
+func NewArgon2IdScheme(a *Argon2Id) Scheme {
+	return &argon2idScheme{a: a}
+}
+
+func (s *argon2idScheme) Prefix() string { return "$" + algorithm + "$" }
+
+func (s *argon2idScheme) Supports(hash string) bool {
+	return strings.HasPrefix(hash, s.Prefix())
+}
+
+func (s *argon2idScheme) Hash(password []byte) (string, error) {
+	return s.a.Generate(password)
+}
+
+func (s *argon2idScheme) Verify(password []byte, hash string) error {
+	return s.a.Compare(password, hash)
+}
+
+// NeedsUpdate treats ErrIncompatibleVersion as needing an update: a hash
+// produced by a stale argon2 version is exactly the drift NeedsRehash
+// exists to flag, and Hasher.Verify only offers its opportunistic
+// upgrade when NeedsUpdate reports true. Any other decode error (e.g.
+// ErrMalformedHash) means hash wasn't well-formed, so Verify will
+// already have failed before NeedsUpdate is ever consulted.
+func (s *argon2idScheme) NeedsUpdate(hash string) bool {
+	needsRehash, err := s.a.NeedsRehash(hash)
+	if err != nil {
+		return errors.Is(err, ErrIncompatibleVersion)
+	}
+	return needsRehash
+}
@@ -0,0 +1,58 @@
+package argon2id
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcryptScheme adapts golang.org/x/crypto/bcrypt to the Scheme interface
+// so legacy bcrypt hashes can be verified, and transparently upgraded,
+// by a Hasher whose primary scheme is argon2id.
+type bcryptScheme struct {
+	cost int
+}
+
+// NewBcryptScheme returns a Scheme backed by bcrypt at the given cost.
+// cost is only used for new hashes produced by Hash; Verify dispatches to
+// whatever cost is encoded in the hash being checked.
+func NewBcryptScheme(cost int) Scheme {
+	return &bcryptScheme{cost: cost}
+}
+
+func (s *bcryptScheme) Prefix() string { return "$2b$" }
+
+func (s *bcryptScheme) Supports(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") ||
+		strings.HasPrefix(hash, "$2b$") ||
+		strings.HasPrefix(hash, "$2y$")
+}
+
+func (s *bcryptScheme) Hash(password []byte) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword(password, s.cost)
+	if err != nil {
+		return "", fmt.Errorf("argon2id: bcrypt: generate: %w", err)
+	}
+	return string(hash), nil
+}
+
+func (s *bcryptScheme) Verify(password []byte, hash string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), password)
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return fmt.Errorf("argon2id: bcrypt: %w", ErrPasswordsDoNotMatch)
+	}
+	if err != nil {
+		return fmt.Errorf("argon2id: bcrypt: compare: %w", err)
+	}
+	return nil
+}
+
+func (s *bcryptScheme) NeedsUpdate(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return false
+	}
+	return cost < s.cost
+}
@@ -0,0 +1,111 @@
+package argon2id
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	pbkdf2Algorithm = `pbkdf2-sha256`
+	pbkdf2Template  = `$pbkdf2-sha256$i=%d$%s$%s`
+	pbkdf2Params    = `i=%d`
+
+	pbkdf2NumParts  = 5
+	pbkdf2ParamsIdx = 2
+	pbkdf2SaltIdx   = 3
+	pbkdf2KeyIdx    = 4
+)
+
+// Pbkdf2Sha256Scheme hashes and verifies passwords with PBKDF2-HMAC-SHA256,
+// encoded as "$pbkdf2-sha256$i=<iterations>$<salt>$<key>". It exists to
+// verify hashes inherited from an older system; a Hasher should prefer
+// argon2id as its primary scheme.
+type Pbkdf2Sha256Scheme struct {
+	iterations int
+	saltLength uint32
+	keyLength  uint32
+}
+
+// NewPbkdf2Sha256Scheme creates a Pbkdf2Sha256Scheme.
+func NewPbkdf2Sha256Scheme(iterations int, saltLength, keyLength uint32) Scheme {
+	return &Pbkdf2Sha256Scheme{iterations: iterations, saltLength: saltLength, keyLength: keyLength}
+}
+
+func (s *Pbkdf2Sha256Scheme) Prefix() string { return "$" + pbkdf2Algorithm + "$" }
+
+func (s *Pbkdf2Sha256Scheme) Supports(hash string) bool {
+	return strings.HasPrefix(hash, s.Prefix())
+}
+
+func (s *Pbkdf2Sha256Scheme) Hash(password []byte) (string, error) {
+	salt := make([]byte, s.saltLength)
+	n, err := rand.Read(salt)
+	if err != nil {
+		return "", fmt.Errorf("argon2id: pbkdf2-sha256: generate salt: %w", err)
+	}
+	if n != int(s.saltLength) {
+		return "", fmt.Errorf("argon2id: pbkdf2-sha256: generate salt: %w", ErrBytesNotRead)
+	}
+
+	key := pbkdf2.Key(password, salt, s.iterations, int(s.keyLength), sha256.New)
+
+	b64Salt := base64.RawStdEncoding.EncodeToString(salt)
+	b64Key := base64.RawStdEncoding.EncodeToString(key)
+
+	return fmt.Sprintf(pbkdf2Template, s.iterations, b64Salt, b64Key), nil
+}
+
+func (s *Pbkdf2Sha256Scheme) Verify(password []byte, hash string) error {
+	parts := strings.Split(hash, splitChar)
+	if len(parts) != pbkdf2NumParts {
+		return fmt.Errorf("argon2id: pbkdf2-sha256: parts: %w", ErrMalformedHash)
+	}
+
+	var iterations int
+	if _, err := fmt.Sscanf(parts[pbkdf2ParamsIdx], pbkdf2Params, &iterations); err != nil {
+		return fmt.Errorf("argon2id: pbkdf2-sha256: scan params: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.Strict().DecodeString(parts[pbkdf2SaltIdx])
+	if err != nil {
+		return fmt.Errorf("argon2id: pbkdf2-sha256: decode salt: %w", err)
+	}
+
+	key, err := base64.RawStdEncoding.Strict().DecodeString(parts[pbkdf2KeyIdx])
+	if err != nil {
+		return fmt.Errorf("argon2id: pbkdf2-sha256: decode key: %w", err)
+	}
+
+	comparisonKey := pbkdf2.Key(password, salt, iterations, len(key), sha256.New)
+
+	if subtle.ConstantTimeCompare(key, comparisonKey) == matching {
+		return nil
+	}
+
+	return fmt.Errorf("argon2id: pbkdf2-sha256: %w", ErrPasswordsDoNotMatch)
+}
+
+func (s *Pbkdf2Sha256Scheme) NeedsUpdate(hash string) bool {
+	parts := strings.Split(hash, splitChar)
+	if len(parts) != pbkdf2NumParts {
+		return false
+	}
+
+	var iterations int
+	if _, err := fmt.Sscanf(parts[pbkdf2ParamsIdx], pbkdf2Params, &iterations); err != nil {
+		return false
+	}
+
+	key, err := base64.RawStdEncoding.Strict().DecodeString(parts[pbkdf2KeyIdx])
+	if err != nil {
+		return false
+	}
+
+	return iterations < s.iterations || uint32(len(key)) < s.keyLength
+}
@@ -0,0 +1,122 @@
+package argon2id
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	scryptAlgorithm = `scrypt`
+	scryptTemplate  = `$scrypt$ln=%d,r=%d,p=%d$%s$%s`
+	scryptParams    = `ln=%d,r=%d,p=%d`
+
+	scryptNumParts  = 5
+	scryptParamsIdx = 2
+	scryptSaltIdx   = 3
+	scryptKeyIdx    = 4
+)
+
+// ScryptScheme hashes and verifies passwords with scrypt, encoded as
+// "$scrypt$ln=<log2 N>,r=<r>,p=<p>$<salt>$<key>" in the style of
+// passlib's scrypt format. It's meant to sit alongside Argon2Id in a
+// Hasher so existing scrypt hashes keep verifying while new ones upgrade
+// to argon2id.
+type ScryptScheme struct {
+	logN       uint8
+	r          int
+	p          int
+	saltLength uint32
+	keyLength  uint32
+}
+
+// NewScryptScheme creates a ScryptScheme. logN is the base-2 logarithm of
+// the scrypt cost parameter N (e.g. 15 for N=32768).
+func NewScryptScheme(logN uint8, r, p int, saltLength, keyLength uint32) Scheme {
+	return &ScryptScheme{logN: logN, r: r, p: p, saltLength: saltLength, keyLength: keyLength}
+}
+
+func (s *ScryptScheme) Prefix() string { return "$" + scryptAlgorithm + "$" }
+
+func (s *ScryptScheme) Supports(hash string) bool {
+	return strings.HasPrefix(hash, s.Prefix())
+}
+
+func (s *ScryptScheme) Hash(password []byte) (string, error) {
+	salt := make([]byte, s.saltLength)
+	n, err := rand.Read(salt)
+	if err != nil {
+		return "", fmt.Errorf("argon2id: scrypt: generate salt: %w", err)
+	}
+	if n != int(s.saltLength) {
+		return "", fmt.Errorf("argon2id: scrypt: generate salt: %w", ErrBytesNotRead)
+	}
+
+	key, err := scrypt.Key(password, salt, 1<<s.logN, s.r, s.p, int(s.keyLength))
+	if err != nil {
+		return "", fmt.Errorf("argon2id: scrypt: derive key: %w", err)
+	}
+
+	b64Salt := base64.RawStdEncoding.EncodeToString(salt)
+	b64Key := base64.RawStdEncoding.EncodeToString(key)
+
+	return fmt.Sprintf(scryptTemplate, s.logN, s.r, s.p, b64Salt, b64Key), nil
+}
+
+func (s *ScryptScheme) Verify(password []byte, hash string) error {
+	parts := strings.Split(hash, splitChar)
+	if len(parts) != scryptNumParts {
+		return fmt.Errorf("argon2id: scrypt: parts: %w", ErrMalformedHash)
+	}
+
+	var logN uint8
+	var r, p int
+	if _, err := fmt.Sscanf(parts[scryptParamsIdx], scryptParams, &logN, &r, &p); err != nil {
+		return fmt.Errorf("argon2id: scrypt: scan params: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.Strict().DecodeString(parts[scryptSaltIdx])
+	if err != nil {
+		return fmt.Errorf("argon2id: scrypt: decode salt: %w", err)
+	}
+
+	key, err := base64.RawStdEncoding.Strict().DecodeString(parts[scryptKeyIdx])
+	if err != nil {
+		return fmt.Errorf("argon2id: scrypt: decode key: %w", err)
+	}
+
+	comparisonKey, err := scrypt.Key(password, salt, 1<<logN, r, p, len(key))
+	if err != nil {
+		return fmt.Errorf("argon2id: scrypt: derive key: %w", err)
+	}
+
+	if subtle.ConstantTimeCompare(key, comparisonKey) == matching {
+		return nil
+	}
+
+	return fmt.Errorf("argon2id: scrypt: %w", ErrPasswordsDoNotMatch)
+}
+
+func (s *ScryptScheme) NeedsUpdate(hash string) bool {
+	parts := strings.Split(hash, splitChar)
+	if len(parts) != scryptNumParts {
+		return false
+	}
+
+	var logN uint8
+	var r, p int
+	if _, err := fmt.Sscanf(parts[scryptParamsIdx], scryptParams, &logN, &r, &p); err != nil {
+		return false
+	}
+
+	key, err := base64.RawStdEncoding.Strict().DecodeString(parts[scryptKeyIdx])
+	if err != nil {
+		return false
+	}
+
+	return logN < s.logN || r < s.r || p < s.p || uint32(len(key)) < s.keyLength
+}
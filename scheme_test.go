@@ -0,0 +1,127 @@
+package argon2id
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestHasherUpgradesLegacyScheme(t *testing.T) {
+	legacy := NewPbkdf2Sha256Scheme(10000, 16, 32)
+	primary := NewArgon2IdScheme(New())
+	h := NewHasher(primary, legacy)
+
+	password := []byte("correct horse battery staple")
+
+	legacyHash, err := legacy.Hash(password)
+	assertGenerate(t, legacyHash, nil, err)
+
+	upgraded, err := h.Verify(password, legacyHash)
+	assert(t, err == nil, "verify: unexpected err: %v", err)
+	assert(t, upgraded != "", "verify: want upgraded hash, got none")
+	assert(t, !h.NeedsUpdate(upgraded), "needs update: want false for freshly upgraded hash")
+
+	hash, err := h.Hash(password)
+	assertGenerate(t, hash, nil, err)
+	assert(t, !h.NeedsUpdate(hash), "needs update: want false for primary scheme hash")
+
+	_, err = h.Verify([]byte("wrong password"), hash)
+	assertCompare(t, ErrPasswordsDoNotMatch, err)
+
+	_, err = h.Verify(password, "not a hash")
+	assertCompare(t, ErrUnknownScheme, err)
+}
+
+func testSchemeUpgrade(t *testing.T, name string, legacy Scheme) {
+	t.Run(name, func(t *testing.T) {
+		primary := NewArgon2IdScheme(New())
+		h := NewHasher(primary, legacy)
+
+		password := []byte("correct horse battery staple")
+
+		legacyHash, err := legacy.Hash(password)
+		assertGenerate(t, legacyHash, nil, err)
+
+		err = legacy.Verify(password, legacyHash)
+		assertCompare(t, nil, err)
+
+		err = legacy.Verify([]byte("wrong password"), legacyHash)
+		assertCompare(t, ErrPasswordsDoNotMatch, err)
+
+		upgraded, err := h.Verify(password, legacyHash)
+		assert(t, err == nil, "verify: unexpected err: %v", err)
+		assert(t, upgraded != "", "verify: want upgraded hash, got none")
+		assert(t, !h.NeedsUpdate(upgraded), "needs update: want false for freshly upgraded hash")
+	})
+}
+
+func TestHasherUpgradesBcryptScheme(t *testing.T) {
+	testSchemeUpgrade(t, "bcrypt", NewBcryptScheme(4))
+}
+
+func TestHasherUpgradesScryptScheme(t *testing.T) {
+	testSchemeUpgrade(t, "scrypt", NewScryptScheme(10, 8, 1, 16, 32))
+}
+
+// failingScheme always fails to produce a hash, simulating a transient
+// error (e.g. rand.Read) on the upgrade path.
+type failingScheme struct {
+	Scheme
+}
+
+func (failingScheme) Hash(password []byte) (string, error) {
+	return "", errors.New("simulated upgrade failure")
+}
+
+func TestHasherVerifyToleratesUpgradeFailure(t *testing.T) {
+	legacy := NewPbkdf2Sha256Scheme(10000, 16, 32)
+	primary := failingScheme{Scheme: NewArgon2IdScheme(New())}
+	h := NewHasher(primary, legacy)
+
+	password := []byte("correct horse battery staple")
+
+	legacyHash, err := legacy.Hash(password)
+	assertGenerate(t, legacyHash, nil, err)
+
+	upgraded, err := h.Verify(password, legacyHash)
+	assert(t, err == nil, "verify: a failed upgrade must not fail verification, got err: %v", err)
+	assert(t, upgraded == "", "verify: want no upgraded hash when upgrade fails, got %q", upgraded)
+}
+
+// TestArgon2SchemeNeedsUpdateOnVersionDrift guards against NeedsUpdate
+// collapsing ErrIncompatibleVersion into "no update needed": a hash
+// recorded against a stale argon2 version is precisely the drift
+// NeedsRehash exists to flag, and a Hasher only ever offers its
+// opportunistic upgrade when NeedsUpdate reports true.
+func TestArgon2SchemeNeedsUpdateOnVersionDrift(t *testing.T) {
+	password := []byte("correct horse battery staple")
+
+	a := New()
+	hash, err := a.Generate(password)
+	assertGenerate(t, hash, nil, err)
+
+	d, err := decode(hash)
+	assert(t, err == nil, "decode: unexpected err: %v", err)
+
+	staleHash := fmt.Sprintf(template, d.variant, d.version+1, d.memoryCost, d.timeCost, d.parallelismCost,
+		base64.RawStdEncoding.EncodeToString(d.salt), base64.RawStdEncoding.EncodeToString(d.key))
+
+	idScheme := NewArgon2IdScheme(a)
+	assertCompare(t, nil, idScheme.Verify(password, staleHash))
+	assert(t, idScheme.NeedsUpdate(staleHash), "argon2id scheme: needs update: want true for a stale argon2 version")
+
+	iA := New(WithVariant(Argon2i))
+	iHash, err := iA.Generate(password)
+	assertGenerate(t, iHash, nil, err)
+
+	di, err := decode(iHash)
+	assert(t, err == nil, "decode: unexpected err: %v", err)
+
+	staleIHash := fmt.Sprintf(template, di.variant, di.version+1, di.memoryCost, di.timeCost, di.parallelismCost,
+		base64.RawStdEncoding.EncodeToString(di.salt), base64.RawStdEncoding.EncodeToString(di.key))
+
+	iScheme := NewArgon2IScheme(iA)
+	assertCompare(t, nil, iScheme.Verify(password, staleIHash))
+	assert(t, iScheme.NeedsUpdate(staleIHash), "argon2i scheme: needs update: want true for a stale argon2 version")
+}
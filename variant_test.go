@@ -0,0 +1,55 @@
+package argon2id
+
+import "testing"
+
+func TestVariants(t *testing.T) {
+	password := []byte("correct horse battery staple")
+
+	idHash, err := New().Generate(password)
+	assertGenerate(t, idHash, nil, err)
+	assert(t, idHash[:len("$argon2id$")] == "$argon2id$", "variant: want argon2id prefix, got %q", idHash)
+
+	iHash, err := New(WithVariant(Argon2i)).Generate(password)
+	assertGenerate(t, iHash, nil, err)
+	assert(t, iHash[:len("$argon2i$")] == "$argon2i$", "variant: want argon2i prefix, got %q", iHash)
+
+	// Either variant verifies against a plain New() instance, since
+	// Compare dispatches on the hash's own prefix.
+	assertCompare(t, nil, New().Compare(password, idHash))
+	assertCompare(t, nil, New().Compare(password, iHash))
+
+	_, err = New(WithVariant(Argon2d)).Generate(password)
+	assertCompare(t, ErrUnsupportedVariant, err)
+}
+
+func TestSecretAndAssociatedData(t *testing.T) {
+	password := []byte("correct horse battery staple")
+
+	a := New(WithSecret([]byte("pepper")), WithAssociatedData([]byte("tenant:acme")))
+	hash, err := a.Generate(password)
+	assertGenerate(t, hash, nil, err)
+
+	assertCompare(t, nil, a.Compare(password, hash))
+
+	withoutSecret := New()
+	assertCompare(t, ErrPasswordsDoNotMatch, withoutSecret.Compare(password, hash))
+
+	wrongSecret := New(WithSecret([]byte("wrong")), WithAssociatedData([]byte("tenant:acme")))
+	assertCompare(t, ErrPasswordsDoNotMatch, wrongSecret.Compare(password, hash))
+
+	wrongAssociatedData := New(WithSecret([]byte("pepper")), WithAssociatedData([]byte("tenant:other")))
+	assertCompare(t, ErrPasswordsDoNotMatch, wrongAssociatedData.Compare(password, hash))
+}
+
+// TestAssociatedDataBoundary guards against password/associatedData pairs
+// that would collide if they were mixed into the HMAC without an
+// unambiguous boundary between them: ("foo", "bar") and ("foob", "ar")
+// concatenate to the same byte stream, "foobar", either way.
+func TestAssociatedDataBoundary(t *testing.T) {
+	a := New(WithSecret([]byte("pepper")), WithAssociatedData([]byte("bar")))
+	hash, err := a.Generate([]byte("foo"))
+	assertGenerate(t, hash, nil, err)
+
+	collider := New(WithSecret([]byte("pepper")), WithAssociatedData([]byte("ar")))
+	assertCompare(t, ErrPasswordsDoNotMatch, collider.Compare([]byte("foob"), hash))
+}